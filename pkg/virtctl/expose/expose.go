@@ -2,14 +2,20 @@ package expose
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/pointer"
 
+	kubevirtv1 "kubevirt.io/client-go/api/v1"
 	"kubevirt.io/kubevirt/pkg/kubecli"
 	"kubevirt.io/kubevirt/pkg/virtctl/templates"
 )
@@ -28,13 +34,25 @@ var serviceName string
 var clusterIP string
 var externalIP string
 var loadBalancerIP string
-var port int32
+var strPorts []string
 var nodePort int32
 var strProtocol string
 var strTargetPort string
 var strServiceType string
 var portName string
 var namespace string
+var strDryRun string
+var strOutput string
+var strSessionAffinity string
+var sessionAffinityTimeoutSeconds int32
+var strExternalTrafficPolicy string
+var strIPFamily string
+var strIPFamilyPolicy string
+var loadBalancerSourceRanges []string
+var noOwnerReference bool
+var strSelector string
+var ifNotExists bool
+var force bool
 
 // generate a new "expose" command
 func NewExposeCommand(clientConfig clientcmd.ClientConfig) *cobra.Command {
@@ -43,11 +61,11 @@ func NewExposeCommand(clientConfig clientcmd.ClientConfig) *cobra.Command {
 		Short: "Expose a virtual machine as a new service.",
 		Long: `Looks up a virtual machine instance, virtual machine or virtual machine instance replica set by name and use its selector as the selector for a new service on the specified port.
 A virtual machine instance replica set will be exposed as a service only if its selector is convertible to a selector that service supports, i.e. when the selector contains only the matchLabels component.
-Note that if no port is specified via --port and the exposed resource has multiple ports, all will be re-used by the new service. 
+Note that if no port is specified via --port and the exposed resource has multiple ports, all will be re-used by the new service.
 Also if no labels are specified, the new service will re-use the labels from the resource it exposes.
-        
+
 Possible types are (case insensitive, both single and plurant forms):
-        
+
 virtualmachineinstance (vmi), virtualmachine (vm), virtualmachineinstancereplicaset (vmirs)`,
 		Example: usage(),
 		Args:    cobra.ExactArgs(2),
@@ -63,13 +81,25 @@ virtualmachineinstance (vmi), virtualmachine (vm), virtualmachineinstancereplica
 	cmd.Flags().StringVar(&clusterIP, "cluster-ip", "", "ClusterIP to be assigned to the service. Leave empty to auto-allocate, or set to 'None' to create a headless service.")
 	cmd.Flags().StringVar(&externalIP, "external-ip", "", "Additional external IP address (not managed by the cluster) to accept for the service. If this IP is routed to a node, the service can be accessed by this IP in addition to its generated service IP. Optional.")
 	cmd.Flags().StringVar(&loadBalancerIP, "load-balancer-ip", "", "IP to assign to the Load Balancer. If empty, an ephemeral IP will be created and used.")
-	cmd.Flags().Int32Var(&port, "port", 0, "The port that the service should serve on")
-	cmd.MarkFlagRequired("port")
-	cmd.Flags().StringVar(&strProtocol, "protocol", "TCP", "The network protocol for the service to be created.")
-	cmd.Flags().StringVar(&strTargetPort, "target-port", "", "Name or number for the port on the VM that the service should direct traffic to. Optional.")
-	cmd.Flags().Int32Var(&nodePort, "node-port", 0, "Port used to expose the service on each node in a cluster.")
+	cmd.Flags().StringArrayVar(&strPorts, "port", nil, "The port or list of ports ([name=]port[:targetPort[:nodePort]][/protocol], comma-separated or repeatable) that the service should serve on. If not specified, every port declared on the exposed resource is re-used.")
+	cmd.Flags().StringVar(&strProtocol, "protocol", "TCP", "The network protocol for the service to be created. Used for any --port that doesn't specify its own protocol.")
+	cmd.Flags().StringVar(&strTargetPort, "target-port", "", "Name or number for the port on the VM that the service should direct traffic to. Used for any --port that doesn't specify its own target port. Optional.")
+	cmd.Flags().Int32Var(&nodePort, "node-port", 0, "Port used to expose the service on each node in a cluster. Used for any --port that doesn't specify its own node port.")
 	cmd.Flags().StringVar(&strServiceType, "type", "ClusterIP", "Type for this service: ClusterIP, NodePort, or LoadBalancer.")
-	cmd.Flags().StringVar(&portName, "port-name", "", "Name of the port. Optional.")
+	cmd.Flags().StringVar(&portName, "port-name", "", "Name of the port. Used for any --port that doesn't specify its own name. Optional.")
+	cmd.Flags().StringVar(&strDryRun, "dry-run", "", `Must be "none", "client", or "server". If "client", only print the object that would be created, without creating it. If "server", submit the request with the dry-run flag set so the apiserver validates it without persisting it.`)
+	cmd.Flags().Lookup("dry-run").NoOptDefVal = "client"
+	cmd.Flags().StringVarP(&strOutput, "output", "o", "", "Output format for the generated service. One of: yaml, json, name. Only used together with --dry-run, or to print the created service.")
+	cmd.Flags().StringVar(&strSessionAffinity, "session-affinity", "", "Set the session affinity for the service: None or ClientIP. Optional.")
+	cmd.Flags().Int32Var(&sessionAffinityTimeoutSeconds, "session-affinity-timeout", 0, "Seconds a ClientIP session stays affine to one virtual machine. Only used with --session-affinity=ClientIP. Optional.")
+	cmd.Flags().StringVar(&strExternalTrafficPolicy, "external-traffic-policy", "", "Set the external traffic policy for the service: Cluster or Local. Only valid for NodePort and LoadBalancer services. Optional.")
+	cmd.Flags().StringVar(&strIPFamily, "ip-family", "", "Restrict the service to one or both IP families: IPv4, IPv6, or dual. Optional.")
+	cmd.Flags().StringVar(&strIPFamilyPolicy, "ip-family-policy", "", "Set the IP family policy for the service: SingleStack, PreferDualStack, or RequireDualStack. Optional.")
+	cmd.Flags().StringSliceVar(&loadBalancerSourceRanges, "load-balancer-source-ranges", nil, "A list of CIDRs allowed to access the LoadBalancer service. Only valid for LoadBalancer services. Optional.")
+	cmd.Flags().BoolVar(&noOwnerReference, "no-owner-reference", false, "Don't set an owner reference to the exposed VM/VMI/VMIRS on the created service, so the service survives deletion of the resource it exposes.")
+	cmd.Flags().StringVar(&strSelector, "selector", "", "A comma-separated key=value label selector to use for the created service, overriding the one derived from the exposed resource. Optional.")
+	cmd.Flags().BoolVar(&ifNotExists, "if-not-exists", false, "If a service with the given name already exists and its selector matches, exit successfully instead of failing.")
+	cmd.Flags().BoolVar(&force, "force", false, "If a service with the given name already exists, delete and recreate it.")
 	cmd.SetUsageTemplate(templates.UsageTemplate())
 
 	return cmd
@@ -77,7 +107,13 @@ virtualmachineinstance (vmi), virtualmachine (vm), virtualmachineinstancereplica
 
 func usage() string {
 	usage := `  # Expose SSH to a virtual machine instance called 'myvm' as a port (5555) and specify each node open up port 30001 on the cluster:
-  virtctl expose vmi myvm --port=5555 --node-port=30001 --target-port=22 --name=myvm-ssh --type=NodePort")`
+  virtctl expose vmi myvm --port=5555 --node-port=30001 --target-port=22 --name=myvm-ssh --type=NodePort
+
+  # Expose multiple ports of a virtual machine instance called 'myvm' at once:
+  virtctl expose vmi myvm --port=web=80,dns=53/UDP --name=myvm-svc
+
+  # Render the Service that would be created, without creating it, so it can be piped into 'kubectl apply -f -':
+  virtctl expose vmi myvm --port=80 --name=myvm-svc --dry-run=client -o yaml`
 	return usage
 }
 
@@ -89,21 +125,25 @@ func (o *Command) RunE(cmd *cobra.Command, args []string) error {
 	vmName := args[1]
 
 	// these are used to convert the flag values into service spec values
-	var protocol v1.Protocol
-	var targetPort intstr.IntOrString
 	var serviceType v1.ServiceType
 
-	// convert from integer to the IntOrString type
-	targetPort = intstr.Parse(strTargetPort)
+	// defaults applied to every --port entry that doesn't specify its own value
+	portDefaults := v1.ServicePort{Name: portName, NodePort: nodePort}
 
 	// convert from string to the protocol enum
-	switch strProtocol {
-	case "TCP":
-		protocol = v1.ProtocolTCP
-	case "UDP":
-		protocol = v1.ProtocolUDP
-	default:
-		return fmt.Errorf("unknown protocol: %s", strProtocol)
+	defaultProtocol, err := parseProtocol(strProtocol)
+	if err != nil {
+		return err
+	}
+	portDefaults.Protocol = defaultProtocol
+
+	if strTargetPort != "" {
+		portDefaults.TargetPort = intstr.Parse(strTargetPort)
+	}
+
+	servicePorts, err := parsePortSpecs(strPorts, portDefaults)
+	if err != nil {
+		return err
 	}
 
 	// convert from string to the service type enum
@@ -135,6 +175,7 @@ func (o *Command) RunE(cmd *cobra.Command, args []string) error {
 	// does a plain quorum read from the apiserver
 	options := k8smetav1.GetOptions{}
 	var serviceSelector map[string]string
+	var ownerReference *k8smetav1.OwnerReference
 
 	switch vmType {
 	case "vmi", "vmis", "virtualmachineinstance", "virtualmachineinstances":
@@ -146,6 +187,13 @@ func (o *Command) RunE(cmd *cobra.Command, args []string) error {
 		serviceSelector = vmi.ObjectMeta.Labels
 		// remove unwanted labels
 		delete(serviceSelector, "kubevirt.io/nodeName")
+		if len(servicePorts) == 0 {
+			servicePorts, err = discoverPorts(vmi.Spec.Domain.Devices.Interfaces)
+			if err != nil {
+				return err
+			}
+		}
+		ownerReference = newOwnerReference(kubevirtv1.VirtualMachineInstanceGroupVersionKind, vmi.ObjectMeta)
 	case "vm", "vms", "virtualmachine", "virtualmachines":
 		// get the offline VM
 		vm, err := virtClient.VirtualMachine(namespace).Get(vmName, &options)
@@ -153,6 +201,13 @@ func (o *Command) RunE(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("error fetching OfflineVirtual: %v", err)
 		}
 		serviceSelector = vm.Spec.Template.ObjectMeta.Labels
+		if len(servicePorts) == 0 {
+			servicePorts, err = discoverPorts(vm.Spec.Template.Spec.Domain.Devices.Interfaces)
+			if err != nil {
+				return err
+			}
+		}
+		ownerReference = newOwnerReference(kubevirtv1.VirtualMachineGroupVersionKind, vm.ObjectMeta)
 	case "vmirs", "vmirss", "virtualmachineinstancereplicaset", "virtualmachineinstancereplicasets":
 		// get the VM replica set
 		vmirs, err := virtClient.ReplicaSet(namespace).Get(vmName, options)
@@ -163,14 +218,49 @@ func (o *Command) RunE(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("cannot expose VirtualMachineInstance ReplicaSet with match expressions")
 		}
 		serviceSelector = vmirs.Spec.Selector.MatchLabels
+		if len(servicePorts) == 0 {
+			servicePorts, err = discoverPorts(vmirs.Spec.Template.Spec.Domain.Devices.Interfaces)
+			if err != nil {
+				return err
+			}
+		}
+		ownerReference = newOwnerReference(kubevirtv1.VirtualMachineInstanceReplicaSetGroupVersionKind, vmirs.ObjectMeta)
 	default:
 		return fmt.Errorf("unsupported resource type: %s", vmType)
 	}
 
+	if strSelector != "" {
+		overrideSelector, err := parseSelector(strSelector)
+		if err != nil {
+			return err
+		}
+		serviceSelector = overrideSelector
+	}
+
 	if len(serviceSelector) == 0 {
 		return fmt.Errorf("missing label information for %s: %s", vmType, vmName)
 	}
 
+	if len(servicePorts) == 0 {
+		return fmt.Errorf("no --port specified and %s %s does not declare any ports to re-use", vmType, vmName)
+	}
+
+	switch strDryRun {
+	case "", "none", "client", "server":
+	default:
+		return fmt.Errorf("unsupported --dry-run value %q, must be one of none, client, server", strDryRun)
+	}
+
+	switch strOutput {
+	case "", "yaml", "json", "name":
+	default:
+		return fmt.Errorf("unsupported output format: %q, must be one of yaml, json, name", strOutput)
+	}
+
+	if ifNotExists && force {
+		return fmt.Errorf("--if-not-exists and --force are mutually exclusive")
+	}
+
 	// actually create the service
 	service := &v1.Service{
 		ObjectMeta: k8smetav1.ObjectMeta{
@@ -178,9 +268,7 @@ func (o *Command) RunE(cmd *cobra.Command, args []string) error {
 			Namespace: namespace,
 		},
 		Spec: v1.ServiceSpec{
-			Ports: []v1.ServicePort{
-				{Name: portName, Protocol: protocol, Port: port, TargetPort: targetPort, NodePort: nodePort},
-			},
+			Ports:          servicePorts,
 			Selector:       serviceSelector,
 			ClusterIP:      clusterIP,
 			Type:           serviceType,
@@ -188,16 +276,126 @@ func (o *Command) RunE(cmd *cobra.Command, args []string) error {
 		},
 	}
 
+	if !noOwnerReference && ownerReference != nil {
+		service.ObjectMeta.OwnerReferences = []k8smetav1.OwnerReference{*ownerReference}
+	}
+
 	// set external IP if provided
 	if len(externalIP) > 0 {
 		service.Spec.ExternalIPs = []string{externalIP}
 	}
 
-	// try to create the service on the cluster
-	_, err = virtClient.CoreV1().Services(namespace).Create(service)
+	if err := applySessionAffinity(&service.Spec, strSessionAffinity, sessionAffinityTimeoutSeconds); err != nil {
+		return err
+	}
+	if err := applyExternalTrafficPolicy(&service.Spec, strExternalTrafficPolicy); err != nil {
+		return err
+	}
+	if err := applyIPFamilies(&service.Spec, strIPFamily, strIPFamilyPolicy); err != nil {
+		return err
+	}
+	if err := applyLoadBalancerSourceRanges(&service.Spec, loadBalancerSourceRanges); err != nil {
+		return err
+	}
+
+	if strDryRun == "client" {
+		// nothing is created, so print the object itself unless -o asks for another format
+		output := strOutput
+		if output == "" {
+			output = "yaml"
+		}
+		return printService(cmd, service, vmType, vmName, output)
+	}
+
+	createOptions := k8smetav1.CreateOptions{}
+	if strDryRun == "server" {
+		createOptions.DryRun = []string{k8smetav1.DryRunAll}
+	}
+
+	// try to create the service on the cluster, honoring --if-not-exists/--force
+	service, err = createOrHandleExisting(virtClient.CoreV1().Services(namespace), service, createOptions, force, ifNotExists)
 	if err != nil {
-		return fmt.Errorf("service creation failed: %v", err)
+		return err
+	}
+
+	return printService(cmd, service, vmType, vmName, strOutput)
+}
+
+// createOrHandleExisting creates service, and if a service with the same
+// name already exists, resolves the conflict according to force/ifNotExists:
+// force deletes and recreates it, ifNotExists succeeds as long as the
+// existing service's selector matches, and otherwise an error saying the
+// service already exists is returned.
+func createOrHandleExisting(services corev1client.ServiceInterface, service *v1.Service, createOptions k8smetav1.CreateOptions, force, ifNotExists bool) (*v1.Service, error) {
+	created, err := services.Create(service, createOptions)
+	switch {
+	case err == nil:
+		return created, nil
+	case !apierrors.IsAlreadyExists(err):
+		return nil, fmt.Errorf("service creation failed: %v", err)
+	case force:
+		if err := services.Delete(service.Name, &k8smetav1.DeleteOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to delete existing service %s for --force: %v", service.Name, err)
+		}
+		created, err := services.Create(service, createOptions)
+		if err != nil {
+			return nil, fmt.Errorf("service creation failed: %v", err)
+		}
+		return created, nil
+	case ifNotExists:
+		existing, err := services.Get(service.Name, k8smetav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("service %s already exists but could not be fetched: %v", service.Name, err)
+		}
+		if !reflect.DeepEqual(existing.Spec.Selector, service.Spec.Selector) {
+			return nil, fmt.Errorf("service %s already exists with a different selector", service.Name)
+		}
+		return existing, nil
+	default:
+		return nil, fmt.Errorf("service creation failed: service %s already exists", service.Name)
 	}
-	fmt.Printf("Service %s successfully exposed for %s %s\n", serviceName, vmType, vmName)
-	return nil
+}
+
+// newOwnerReference builds an OwnerReference pointing at the exposed
+// resource so that deleting it also lets a garbage collector, or tooling
+// like kubectl tree, associate the created Service with it. It is not the
+// controller of the Service: deleting the Service must not requeue the VM.
+func newOwnerReference(gvk schema.GroupVersionKind, meta k8smetav1.ObjectMeta) *k8smetav1.OwnerReference {
+	return &k8smetav1.OwnerReference{
+		APIVersion:         gvk.GroupVersion().String(),
+		Kind:               gvk.Kind,
+		Name:               meta.Name,
+		UID:                meta.UID,
+		Controller:         pointer.Bool(false),
+		BlockOwnerDeletion: pointer.Bool(true),
+	}
+}
+
+// discoverPorts returns the ServicePorts declared on each interface's Ports
+// list, used when --port is omitted and the exposed resource declares its
+// own ports (e.g. for the masquerade binding). It is subject to the same
+// port-naming rules as --port, so an unnamed multi-port spec is rejected
+// client-side instead of failing in the apiserver.
+func discoverPorts(interfaces []kubevirtv1.Interface) ([]v1.ServicePort, error) {
+	var result []v1.ServicePort
+	for _, iface := range interfaces {
+		for _, p := range iface.Ports {
+			protocol, err := parseProtocol(p.Protocol)
+			if err != nil {
+				protocol = v1.ProtocolTCP
+			}
+			result = append(result, v1.ServicePort{
+				Name:       p.Name,
+				Protocol:   protocol,
+				Port:       p.Port,
+				TargetPort: intstr.FromInt(int(p.Port)),
+			})
+		}
+	}
+
+	if err := validatePortNames(result); err != nil {
+		return nil, fmt.Errorf("cannot auto-discover ports: %v", err)
+	}
+
+	return result, nil
 }
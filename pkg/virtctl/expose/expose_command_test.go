@@ -0,0 +1,73 @@
+package expose
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/api/core/v1"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("createOrHandleExisting", func() {
+
+	const namespace = "default"
+
+	var kubeClient *fake.Clientset
+	var service *v1.Service
+
+	BeforeEach(func() {
+		kubeClient = fake.NewSimpleClientset()
+		service = &v1.Service{
+			ObjectMeta: k8smetav1.ObjectMeta{Name: "myvm-svc", Namespace: namespace},
+			Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "myvm"}},
+		}
+	})
+
+	It("creates the service when none exists", func() {
+		created, err := createOrHandleExisting(kubeClient.CoreV1().Services(namespace), service, k8smetav1.CreateOptions{}, false, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created.Name).To(Equal("myvm-svc"))
+
+		_, err = kubeClient.CoreV1().Services(namespace).Get(service.Name, k8smetav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("fails by default when a service with the same name already exists", func() {
+		_, err := kubeClient.CoreV1().Services(namespace).Create(service, k8smetav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = createOrHandleExisting(kubeClient.CoreV1().Services(namespace), service, k8smetav1.CreateOptions{}, false, false)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("--if-not-exists succeeds when the existing service's selector matches", func() {
+		_, err := kubeClient.CoreV1().Services(namespace).Create(service, k8smetav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := createOrHandleExisting(kubeClient.CoreV1().Services(namespace), service, k8smetav1.CreateOptions{}, false, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Name).To(Equal(service.Name))
+	})
+
+	It("--if-not-exists fails when the existing service's selector differs", func() {
+		existing := service.DeepCopy()
+		existing.Spec.Selector = map[string]string{"app": "other"}
+		_, err := kubeClient.CoreV1().Services(namespace).Create(existing, k8smetav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = createOrHandleExisting(kubeClient.CoreV1().Services(namespace), service, k8smetav1.CreateOptions{}, false, true)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("--force deletes and recreates the existing service", func() {
+		existing := service.DeepCopy()
+		existing.Spec.Selector = map[string]string{"app": "other"}
+		_, err := kubeClient.CoreV1().Services(namespace).Create(existing, k8smetav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := createOrHandleExisting(kubeClient.CoreV1().Services(namespace), service, k8smetav1.CreateOptions{}, true, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Spec.Selector).To(Equal(map[string]string{"app": "myvm"}))
+	})
+})
@@ -0,0 +1,49 @@
+package expose
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	kubevirtv1 "kubevirt.io/client-go/api/v1"
+)
+
+var _ = Describe("newOwnerReference", func() {
+	It("should reference the source object as a non-controller", func() {
+		gvk := schema.GroupVersionKind{Group: "kubevirt.io", Version: "v1", Kind: "VirtualMachineInstance"}
+		meta := k8smetav1.ObjectMeta{Name: "myvm", UID: types.UID("abc-123")}
+
+		ref := newOwnerReference(gvk, meta)
+
+		Expect(ref.APIVersion).To(Equal("kubevirt.io/v1"))
+		Expect(ref.Kind).To(Equal("VirtualMachineInstance"))
+		Expect(ref.Name).To(Equal("myvm"))
+		Expect(ref.UID).To(Equal(types.UID("abc-123")))
+		Expect(*ref.Controller).To(BeFalse())
+		Expect(*ref.BlockOwnerDeletion).To(BeTrue())
+	})
+})
+
+var _ = Describe("discoverPorts", func() {
+	It("should reject multiple unnamed ports, just like --port does", func() {
+		interfaces := []kubevirtv1.Interface{
+			{Ports: []kubevirtv1.Port{{Port: 80}, {Port: 443}}},
+		}
+
+		_, err := discoverPorts(interfaces)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should accept multiple ports when they are named", func() {
+		interfaces := []kubevirtv1.Interface{
+			{Ports: []kubevirtv1.Port{{Name: "http", Port: 80}, {Name: "https", Port: 443}}},
+		}
+
+		ports, err := discoverPorts(interfaces)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,152 @@
+package expose
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// parsePortSpecs turns the raw --port values into a list of ServicePorts.
+// Each value may itself be a comma-separated list (e.g. "80:8080/TCP,53:5353/UDP")
+// and the flag may also be repeated; both forms are merged into a single list.
+//
+// The grammar accepted for a single port is:
+//
+//	[name=]port[:targetPort[:nodePort]][/protocol]
+//
+// Any field left out of a spec falls back to the corresponding value in
+// defaults, which is populated from the --target-port, --node-port,
+// --port-name and --protocol flags so that the single-port invocation kubectl
+// users are used to keeps working unchanged. A target port that is left out
+// when defaults has none is set to the port itself.
+func parsePortSpecs(rawPorts []string, defaults v1.ServicePort) ([]v1.ServicePort, error) {
+	var specs []string
+	for _, p := range rawPorts {
+		specs = append(specs, strings.Split(p, ",")...)
+	}
+
+	result := make([]v1.ServicePort, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		servicePort, err := parsePortSpec(spec, defaults)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --port %q: %v", spec, err)
+		}
+
+		result = append(result, servicePort)
+	}
+
+	if err := validatePortNames(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// validatePortNames enforces the naming rules a Service's Ports must follow:
+// every name must be unique, and once there is more than one port, every
+// port must be named. Applying this to any port list before it reaches the
+// apiserver turns an opaque server-side validation error into a clear,
+// client-side one, regardless of whether the ports came from --port or were
+// auto-discovered from the exposed resource.
+func validatePortNames(ports []v1.ServicePort) error {
+	seenNames := map[string]bool{}
+	for _, servicePort := range ports {
+		if servicePort.Name == "" {
+			continue
+		}
+		if seenNames[servicePort.Name] {
+			return fmt.Errorf("duplicate port name %q", servicePort.Name)
+		}
+		seenNames[servicePort.Name] = true
+	}
+
+	if len(ports) > 1 {
+		for _, servicePort := range ports {
+			if servicePort.Name == "" {
+				return fmt.Errorf("must specify a name for every port (e.g. name=port) when exposing more than one port")
+			}
+		}
+	}
+
+	return nil
+}
+
+func parsePortSpec(spec string, defaults v1.ServicePort) (v1.ServicePort, error) {
+	servicePort := defaults
+
+	if idx := strings.Index(spec, "="); idx != -1 {
+		servicePort.Name = spec[:idx]
+		spec = spec[idx+1:]
+	}
+
+	if idx := strings.LastIndex(spec, "/"); idx != -1 {
+		protocol, err := parseProtocol(spec[idx+1:])
+		if err != nil {
+			return v1.ServicePort{}, err
+		}
+		servicePort.Protocol = protocol
+		spec = spec[:idx]
+	}
+
+	fields := strings.Split(spec, ":")
+	if len(fields) > 3 {
+		return v1.ServicePort{}, fmt.Errorf("too many ':'-separated fields, expected port[:targetPort[:nodePort]]")
+	}
+
+	port, err := parsePortNumber(fields[0])
+	if err != nil {
+		return v1.ServicePort{}, err
+	}
+	servicePort.Port = port
+
+	if len(fields) > 1 && fields[1] != "" {
+		servicePort.TargetPort = intstr.Parse(fields[1])
+	} else if defaults.TargetPort == (intstr.IntOrString{}) {
+		servicePort.TargetPort = intstr.FromInt(int(port))
+	}
+
+	if len(fields) > 2 && fields[2] != "" {
+		nodePort, err := parsePortNumber(fields[2])
+		if err != nil {
+			return v1.ServicePort{}, fmt.Errorf("invalid node port: %v", err)
+		}
+		servicePort.NodePort = nodePort
+	}
+
+	return servicePort, nil
+}
+
+func parsePortNumber(s string) (int32, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %q: %v", s, err)
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q: must be between 1 and 65535", s)
+	}
+	return int32(port), nil
+}
+
+// parseProtocol validates s against the protocols v1.Protocol defines.
+// Matching is case-insensitive so --protocol=tcp works like kubectl's
+// service generator.
+func parseProtocol(s string) (v1.Protocol, error) {
+	switch strings.ToUpper(s) {
+	case string(v1.ProtocolTCP):
+		return v1.ProtocolTCP, nil
+	case string(v1.ProtocolUDP):
+		return v1.ProtocolUDP, nil
+	case string(v1.ProtocolSCTP):
+		return v1.ProtocolSCTP, nil
+	default:
+		return "", fmt.Errorf("unsupported protocol %q, must be one of TCP, UDP, SCTP", s)
+	}
+}
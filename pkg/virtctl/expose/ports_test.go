@@ -0,0 +1,107 @@
+package expose
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestPorts(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Ports Suite")
+}
+
+var _ = Describe("parsePortSpecs", func() {
+
+	defaults := v1.ServicePort{Protocol: v1.ProtocolTCP}
+
+	It("should parse a single port", func() {
+		ports, err := parsePortSpecs([]string{"80"}, defaults)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports).To(Equal([]v1.ServicePort{
+			{Protocol: v1.ProtocolTCP, Port: 80, TargetPort: intstr.FromInt(80)},
+		}))
+	})
+
+	It("should fall back to the --target-port default when a bare port is given", func() {
+		withTargetPort := v1.ServicePort{Protocol: v1.ProtocolTCP, TargetPort: intstr.Parse("22"), NodePort: 30001}
+		ports, err := parsePortSpecs([]string{"5555"}, withTargetPort)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports).To(Equal([]v1.ServicePort{
+			{Protocol: v1.ProtocolTCP, Port: 5555, TargetPort: intstr.Parse("22"), NodePort: 30001},
+		}))
+	})
+
+	It("should parse port, targetPort and protocol", func() {
+		ports, err := parsePortSpecs([]string{"80:8080/UDP"}, defaults)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports).To(Equal([]v1.ServicePort{
+			{Protocol: v1.ProtocolUDP, Port: 80, TargetPort: intstr.FromInt(8080)},
+		}))
+	})
+
+	It("should parse a comma-separated mixed-protocol list, as kubectl's generator does", func() {
+		ports, err := parsePortSpecs([]string{"web=80:8080/TCP,dns=53:5353/UDP"}, defaults)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports).To(Equal([]v1.ServicePort{
+			{Name: "web", Protocol: v1.ProtocolTCP, Port: 80, TargetPort: intstr.FromInt(8080)},
+			{Name: "dns", Protocol: v1.ProtocolUDP, Port: 53, TargetPort: intstr.FromInt(5353)},
+		}))
+	})
+
+	It("should merge repeated --port flags with comma-separated ones", func() {
+		ports, err := parsePortSpecs([]string{"web=80,ssh=22", "dns=53/UDP"}, defaults)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports).To(HaveLen(3))
+	})
+
+	It("should accept an SCTP port", func() {
+		ports, err := parsePortSpecs([]string{"80/SCTP"}, defaults)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports[0].Protocol).To(Equal(v1.ProtocolSCTP))
+	})
+
+	It("should reject duplicate port names", func() {
+		_, err := parsePortSpecs([]string{"web=80,web=81"}, defaults)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject an unnamed port when exposing more than one", func() {
+		_, err := parsePortSpecs([]string{"80,81"}, defaults)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject an out-of-range port", func() {
+		_, err := parsePortSpecs([]string{"70000"}, defaults)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject an unknown protocol", func() {
+		_, err := parsePortSpecs([]string{"80/FOO"}, defaults)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should accept a lowercase protocol", func() {
+		ports, err := parsePortSpecs([]string{"80/udp"}, defaults)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ports[0].Protocol).To(Equal(v1.ProtocolUDP))
+	})
+})
+
+var _ = Describe("parseProtocol", func() {
+	It("should accept TCP, UDP and SCTP case-insensitively", func() {
+		for _, s := range []string{"tcp", "TCP", "Udp", "sctp"} {
+			_, err := parseProtocol(s)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+
+	It("should reject anything else", func() {
+		_, err := parseProtocol("icmp")
+		Expect(err).To(HaveOccurred())
+	})
+})
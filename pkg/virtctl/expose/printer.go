@@ -0,0 +1,43 @@
+package expose
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// printService renders service to cmd's output stream according to output,
+// which must be "", "yaml", "json" or "name". An empty output keeps the
+// human-readable success message virtctl expose has always printed.
+func printService(cmd *cobra.Command, service *v1.Service, vmType, vmName, output string) error {
+	switch output {
+	case "":
+		fmt.Fprintf(cmd.OutOrStdout(), "Service %s successfully exposed for %s %s\n", service.Name, vmType, vmName)
+		return nil
+	case "name":
+		fmt.Fprintf(cmd.OutOrStdout(), "service/%s\n", service.Name)
+		return nil
+	case "yaml":
+		service.TypeMeta = k8smetav1.TypeMeta{Kind: "Service", APIVersion: "v1"}
+		out, err := yaml.Marshal(service)
+		if err != nil {
+			return fmt.Errorf("failed to marshal service: %v", err)
+		}
+		fmt.Fprint(cmd.OutOrStdout(), string(out))
+		return nil
+	case "json":
+		service.TypeMeta = k8smetav1.TypeMeta{Kind: "Service", APIVersion: "v1"}
+		out, err := json.MarshalIndent(service, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal service: %v", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(out))
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %q, must be one of yaml, json, name", output)
+	}
+}
@@ -0,0 +1,54 @@
+package expose
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"k8s.io/api/core/v1"
+)
+
+var _ = Describe("printService", func() {
+
+	var cmd *cobra.Command
+	var out *bytes.Buffer
+	var service *v1.Service
+
+	BeforeEach(func() {
+		cmd = &cobra.Command{}
+		out = &bytes.Buffer{}
+		cmd.SetOut(out)
+		service = &v1.Service{}
+		service.Name = "myvm-svc"
+	})
+
+	It("should print the default success message when output is empty", func() {
+		Expect(printService(cmd, service, "vmi", "myvm", "")).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("successfully exposed"))
+	})
+
+	It("should print just the qualified name for -o name", func() {
+		Expect(printService(cmd, service, "vmi", "myvm", "name")).To(Succeed())
+		Expect(out.String()).To(Equal("service/myvm-svc\n"))
+	})
+
+	It("should print YAML for -o yaml, with kind and apiVersion set so it can be piped into kubectl apply", func() {
+		Expect(printService(cmd, service, "vmi", "myvm", "yaml")).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("name: myvm-svc"))
+		Expect(out.String()).To(ContainSubstring("kind: Service"))
+		Expect(out.String()).To(ContainSubstring("apiVersion: v1"))
+	})
+
+	It("should print JSON for -o json, with kind and apiVersion set so it can be piped into kubectl apply", func() {
+		Expect(printService(cmd, service, "vmi", "myvm", "json")).To(Succeed())
+		Expect(out.String()).To(ContainSubstring(`"name": "myvm-svc"`))
+		Expect(out.String()).To(ContainSubstring(`"kind": "Service"`))
+		Expect(out.String()).To(ContainSubstring(`"apiVersion": "v1"`))
+	})
+
+	It("should reject an unknown output format", func() {
+		Expect(printService(cmd, service, "vmi", "myvm", "table")).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,25 @@
+package expose
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseSelector parses a comma-separated key=value list, as accepted by
+// --selector, into a label map. An empty string returns a nil map so callers
+// can tell "not set" apart from "set to nothing".
+func parseSelector(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	result := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid selector %q: expected key=value", pair)
+		}
+		result[kv[0]] = kv[1]
+	}
+	return result, nil
+}
@@ -0,0 +1,25 @@
+package expose
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("parseSelector", func() {
+	It("should return nil for an empty selector", func() {
+		selector, err := parseSelector("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(selector).To(BeNil())
+	})
+
+	It("should parse a comma-separated key=value list", func() {
+		selector, err := parseSelector("app=myvm,tier=frontend")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(selector).To(Equal(map[string]string{"app": "myvm", "tier": "frontend"}))
+	})
+
+	It("should reject a pair without a value", func() {
+		_, err := parseSelector("app")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,101 @@
+package expose
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/api/core/v1"
+)
+
+// applySessionAffinity translates --session-affinity and
+// --session-affinity-timeout into the corresponding ServiceSpec fields.
+func applySessionAffinity(spec *v1.ServiceSpec, strAffinity string, timeoutSeconds int32) error {
+	switch strAffinity {
+	case "":
+		return nil
+	case string(v1.ServiceAffinityNone):
+		spec.SessionAffinity = v1.ServiceAffinityNone
+	case string(v1.ServiceAffinityClientIP):
+		spec.SessionAffinity = v1.ServiceAffinityClientIP
+		if timeoutSeconds != 0 {
+			spec.SessionAffinityConfig = &v1.SessionAffinityConfig{
+				ClientIP: &v1.ClientIPConfig{TimeoutSeconds: &timeoutSeconds},
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported --session-affinity value %q, must be one of None, ClientIP", strAffinity)
+	}
+	return nil
+}
+
+// applyExternalTrafficPolicy translates --external-traffic-policy into the
+// corresponding ServiceSpec field. It only makes sense for NodePort and
+// LoadBalancer services.
+func applyExternalTrafficPolicy(spec *v1.ServiceSpec, strPolicy string) error {
+	switch strPolicy {
+	case "":
+		return nil
+	case string(v1.ServiceExternalTrafficPolicyTypeCluster):
+		spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeCluster
+	case string(v1.ServiceExternalTrafficPolicyTypeLocal):
+		spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeLocal
+	default:
+		return fmt.Errorf("unsupported --external-traffic-policy value %q, must be one of Cluster, Local", strPolicy)
+	}
+	if spec.Type != v1.ServiceTypeNodePort && spec.Type != v1.ServiceTypeLoadBalancer {
+		return fmt.Errorf("--external-traffic-policy is only supported for NodePort and LoadBalancer services")
+	}
+	return nil
+}
+
+// applyIPFamilies translates --ip-family and --ip-family-policy into the
+// corresponding ServiceSpec fields.
+func applyIPFamilies(spec *v1.ServiceSpec, strFamily, strFamilyPolicy string) error {
+	switch strFamilyPolicy {
+	case "":
+	case string(v1.IPFamilyPolicySingleStack):
+		policy := v1.IPFamilyPolicySingleStack
+		spec.IPFamilyPolicy = &policy
+	case string(v1.IPFamilyPolicyPreferDualStack):
+		policy := v1.IPFamilyPolicyPreferDualStack
+		spec.IPFamilyPolicy = &policy
+	case string(v1.IPFamilyPolicyRequireDualStack):
+		policy := v1.IPFamilyPolicyRequireDualStack
+		spec.IPFamilyPolicy = &policy
+	default:
+		return fmt.Errorf("unsupported --ip-family-policy value %q, must be one of SingleStack, PreferDualStack, RequireDualStack", strFamilyPolicy)
+	}
+
+	if strFamily == "" {
+		return nil
+	}
+
+	for _, f := range strings.Split(strFamily, ",") {
+		switch strings.TrimSpace(f) {
+		case "IPv4":
+			spec.IPFamilies = append(spec.IPFamilies, v1.IPv4Protocol)
+		case "IPv6":
+			spec.IPFamilies = append(spec.IPFamilies, v1.IPv6Protocol)
+		case "dual":
+			spec.IPFamilies = append(spec.IPFamilies, v1.IPv4Protocol, v1.IPv6Protocol)
+		default:
+			return fmt.Errorf("unsupported --ip-family value %q, must be one of IPv4, IPv6, dual", f)
+		}
+	}
+	return nil
+}
+
+// applyLoadBalancerSourceRanges translates --load-balancer-source-ranges
+// into the corresponding ServiceSpec field. It only makes sense for
+// LoadBalancer services.
+func applyLoadBalancerSourceRanges(spec *v1.ServiceSpec, ranges []string) error {
+	if len(ranges) == 0 {
+		return nil
+	}
+	if spec.Type != v1.ServiceTypeLoadBalancer {
+		return fmt.Errorf("--load-balancer-source-ranges is only supported for LoadBalancer services")
+	}
+	spec.LoadBalancerSourceRanges = ranges
+	return nil
+}
+
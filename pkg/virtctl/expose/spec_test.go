@@ -0,0 +1,74 @@
+package expose
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/api/core/v1"
+)
+
+var _ = Describe("applySessionAffinity", func() {
+	It("should leave the spec untouched when unset", func() {
+		spec := v1.ServiceSpec{}
+		Expect(applySessionAffinity(&spec, "", 0)).To(Succeed())
+		Expect(spec.SessionAffinity).To(BeEmpty())
+	})
+
+	It("should set a ClientIP timeout", func() {
+		spec := v1.ServiceSpec{}
+		Expect(applySessionAffinity(&spec, "ClientIP", 100)).To(Succeed())
+		Expect(spec.SessionAffinity).To(Equal(v1.ServiceAffinityClientIP))
+		Expect(*spec.SessionAffinityConfig.ClientIP.TimeoutSeconds).To(Equal(int32(100)))
+	})
+
+	It("should reject an unknown value", func() {
+		spec := v1.ServiceSpec{}
+		Expect(applySessionAffinity(&spec, "Bogus", 0)).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("applyExternalTrafficPolicy", func() {
+	It("should reject Local for a ClusterIP service", func() {
+		spec := v1.ServiceSpec{Type: v1.ServiceTypeClusterIP}
+		Expect(applyExternalTrafficPolicy(&spec, "Local")).To(HaveOccurred())
+	})
+
+	It("should accept Local for a NodePort service", func() {
+		spec := v1.ServiceSpec{Type: v1.ServiceTypeNodePort}
+		Expect(applyExternalTrafficPolicy(&spec, "Local")).To(Succeed())
+		Expect(spec.ExternalTrafficPolicy).To(Equal(v1.ServiceExternalTrafficPolicyTypeLocal))
+	})
+})
+
+var _ = Describe("applyIPFamilies", func() {
+	It("should expand 'dual' into both IP families", func() {
+		spec := v1.ServiceSpec{}
+		Expect(applyIPFamilies(&spec, "dual", "RequireDualStack")).To(Succeed())
+		Expect(spec.IPFamilies).To(Equal([]v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol}))
+		Expect(*spec.IPFamilyPolicy).To(Equal(v1.IPFamilyPolicyRequireDualStack))
+	})
+
+	It("should reject an unknown family", func() {
+		spec := v1.ServiceSpec{}
+		Expect(applyIPFamilies(&spec, "IPv7", "")).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("applyLoadBalancerSourceRanges", func() {
+	It("should leave the spec untouched when unset", func() {
+		spec := v1.ServiceSpec{Type: v1.ServiceTypeClusterIP}
+		Expect(applyLoadBalancerSourceRanges(&spec, nil)).To(Succeed())
+		Expect(spec.LoadBalancerSourceRanges).To(BeEmpty())
+	})
+
+	It("should reject it for a ClusterIP service", func() {
+		spec := v1.ServiceSpec{Type: v1.ServiceTypeClusterIP}
+		Expect(applyLoadBalancerSourceRanges(&spec, []string{"10.0.0.0/8"})).To(HaveOccurred())
+	})
+
+	It("should accept it for a LoadBalancer service", func() {
+		spec := v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer}
+		Expect(applyLoadBalancerSourceRanges(&spec, []string{"10.0.0.0/8"})).To(Succeed())
+		Expect(spec.LoadBalancerSourceRanges).To(Equal([]string{"10.0.0.0/8"}))
+	})
+})